@@ -0,0 +1,110 @@
+package weightedrand
+
+import (
+	"math"
+	"math/rand"
+)
+
+// AliasChooser selects a weighted random item in O(1) after an O(n)
+// preprocessing step, using Walker's alias method. It trades the
+// O(log n) binary search (or Fenwick descent) Chooser performs on every
+// Pick for a larger, fixed preprocessing cost, which pays off under
+// heavy Pick traffic against a weight table that rarely changes.
+type AliasChooser(type T) struct {
+	data  []Choice(T)
+	prob  []uint64
+	alias []int
+	total uint64
+}
+
+// NewAliasChooser builds an AliasChooser for picking from the provided
+// Choices. Construction is O(n); Pick is O(1).
+//
+// Choices with zero weight are dropped, mirroring NewChooser. It returns
+// ErrNoValidChoices if cs is empty or every Choice has zero weight, and
+// ErrWeightOverflow if the cumulative weight, or any single weight
+// scaled by the number of nonzero-weight Choices, would overflow
+// math.MaxInt64.
+func NewAliasChooser(type T)(cs ...Choice(T)) (AliasChooser(T), error) {
+	filtered := make([]Choice(T), 0, len(cs))
+	for _, c := range cs {
+		if c.Weight > 0 {
+			filtered = append(filtered, c)
+		}
+	}
+	if len(filtered) == 0 {
+		return AliasChooser(T){}, ErrNoValidChoices
+	}
+
+	n := len(filtered)
+	var total uint64
+	for _, c := range filtered {
+		w := uint64(c.Weight)
+		if total > math.MaxInt64-w {
+			return AliasChooser(T){}, ErrWeightOverflow
+		}
+		total += w
+	}
+
+	ac := AliasChooser(T){data: filtered, prob: make([]uint64, n), alias: make([]int, n), total: total}
+
+	// Scale each weight by n so the mean scaled weight is exactly
+	// total, i.e. "1" in the unscaled formulation of the algorithm.
+	// Keeping everything in integer arithmetic avoids float rounding
+	// error near the small/large boundary.
+	scaled := make([]uint64, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i, c := range filtered {
+		w := uint64(c.Weight)
+		if w > uint64(math.MaxInt64)/uint64(n) {
+			return AliasChooser(T){}, ErrWeightOverflow
+		}
+		scaled[i] = w * uint64(n)
+		if scaled[i] < total {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		ac.prob[s] = scaled[s]
+		ac.alias[s] = l
+
+		scaled[l] = scaled[l] + scaled[s] - total
+		if scaled[l] < total {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+	for _, i := range large {
+		ac.prob[i] = total
+	}
+	for _, i := range small {
+		ac.prob[i] = total
+	}
+
+	return ac, nil
+}
+
+// Pick returns a single weighted random Choice.Item from the
+// AliasChooser in O(1). Pick is panic-free: it returns the zero value
+// of T if the AliasChooser is zero-initialized.
+func (ac AliasChooser(T)) Pick() T {
+	if len(ac.data) == 0 {
+		var zero T
+		return zero
+	}
+	i := rand.Intn(len(ac.data))
+	if uint64(rand.Int63n(int64(ac.total))) < ac.prob[i] {
+		return ac.data[i].Item
+	}
+	return ac.data[ac.alias[i]].Item
+}
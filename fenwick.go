@@ -0,0 +1,82 @@
+package weightedrand
+
+// fenwick is a Binary Indexed Tree (BIT) over a fixed-size set of
+// nonnegative integer weights, supporting O(log n) point updates and
+// O(log n) prefix-sum queries. It backs without-replacement selection
+// in PickN/PickRemove, where removing an entry from a presorted
+// cumulative-totals slice would otherwise invalidate the binary search
+// Pick relies on.
+type fenwick struct {
+	tree []int
+	n    int
+}
+
+// newFenwick builds a fenwick tree seeded with the given weights.
+func newFenwick(weights []int) *fenwick {
+	f := &fenwick{tree: make([]int, len(weights)+1), n: len(weights)}
+	for i, w := range weights {
+		f.add(i, w)
+	}
+	return f
+}
+
+// add applies delta to the weight at index i (0-based).
+func (f *fenwick) add(i, delta int) {
+	for i++; i <= f.n; i += i & (-i) {
+		f.tree[i] += delta
+	}
+}
+
+// grow appends a new leaf with weight w to the tree and returns its
+// 0-based index. A naive append-and-update is NOT safe here: a BIT node
+// whose responsibility range only grows to include earlier leaves once
+// n increases past a power-of-two boundary was never summed over those
+// leaves in the first place, since their original add calls stopped
+// propagating once they ran past the old n. So grow extracts the
+// current per-leaf weights and rebuilds the tree from scratch, which is
+// O(n log n) but correct; reaching full O(log n) growth would require
+// retroactively folding old leaves into newly in-range ancestors.
+func (f *fenwick) grow(w int) int {
+	weights := make([]int, f.n+1)
+	prev := 0
+	for i := 0; i < f.n; i++ {
+		cur := f.prefixSum(i)
+		weights[i] = cur - prev
+		prev = cur
+	}
+	weights[f.n] = w
+	*f = *newFenwick(weights)
+	return f.n - 1
+}
+
+// total returns the sum of all weights currently in the tree.
+func (f *fenwick) total() int {
+	return f.prefixSum(f.n - 1)
+}
+
+// prefixSum returns the sum of weights in [0, i].
+func (f *fenwick) prefixSum(i int) int {
+	sum := 0
+	for i++; i > 0; i -= i & (-i) {
+		sum += f.tree[i]
+	}
+	return sum
+}
+
+// find returns the smallest 0-based index whose prefix sum is >= r.
+// r must be in [1, f.total()].
+func (f *fenwick) find(r int) int {
+	pos := 0
+	logN := 0
+	for (1 << uint(logN+1)) <= f.n {
+		logN++
+	}
+	for pw := 1 << uint(logN); pw > 0; pw >>= 1 {
+		next := pos + pw
+		if next <= f.n && f.tree[next] < r {
+			pos = next
+			r -= f.tree[next]
+		}
+	}
+	return pos
+}
@@ -0,0 +1,67 @@
+package weightedrand
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// chooserConfig holds the RNG configuration applied by NewChooserWithOptions.
+// rand.Rand is not safe for concurrent use, so mu guards rng across
+// concurrent Pick/PickN calls against the same Chooser; it serializes
+// those calls the way the default global source's internal lock would,
+// but scoped to Choosers that share this config rather than every
+// Chooser in the process.
+type chooserConfig struct {
+	mu        sync.Mutex
+	rng       *rand.Rand
+	useCrypto bool
+}
+
+// Option configures a Chooser constructed via NewChooserWithOptions.
+type Option func(*chooserConfig)
+
+// WithRand configures the Chooser to draw from r instead of the global
+// math/rand source. Pick/PickN calls against the resulting Chooser (and
+// any copies of it) are safe to make concurrently: they serialize their
+// use of r behind an internal lock, which r itself does not provide.
+func WithRand(r *rand.Rand) Option {
+	return func(c *chooserConfig) { c.rng = r }
+}
+
+// WithRandSource configures the Chooser to draw from a *rand.Rand seeded
+// with src, e.g. to make picks reproducible. As with WithRand, the
+// resulting Chooser serializes access to that *rand.Rand internally, so
+// concurrent Pick/PickN calls against it are safe.
+func WithRandSource(src rand.Source) Option {
+	return func(c *chooserConfig) { c.rng = rand.New(src) }
+}
+
+// WithCryptoRand configures the Chooser to draw randomness from
+// crypto/rand.Reader instead of a math/rand source, rejection-sampling to
+// avoid modulo bias. Use this when Pick results must be
+// cryptographically unpredictable.
+func WithCryptoRand() Option {
+	return func(c *chooserConfig) { c.useCrypto = true }
+}
+
+// cryptoIntn returns a cryptographically random int in [0, n) read from
+// crypto/rand.Reader, rejection-sampling to avoid modulo bias.
+func cryptoIntn(n int) int {
+	if n <= 0 {
+		panic("weightedrand: argument to cryptoIntn must be positive")
+	}
+	limit := math.MaxUint64 - math.MaxUint64%uint64(n)
+	var buf [8]byte
+	for {
+		if _, err := cryptorand.Read(buf[:]); err != nil {
+			panic(err)
+		}
+		u := binary.BigEndian.Uint64(buf[:])
+		if u < limit {
+			return int(u % uint64(n))
+		}
+	}
+}
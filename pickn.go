@@ -0,0 +1,59 @@
+package weightedrand
+
+import "errors"
+
+// ErrInsufficientChoices is returned by PickN when the Chooser does not
+// have enough entries with nonzero weight to satisfy the requested draw
+// count.
+var ErrInsufficientChoices = errors.New("weightedrand: not enough nonzero-weight choices to satisfy PickN")
+
+// PickN draws n distinct items from the Chooser without replacement,
+// proportionally to their weights. PickN never mutates the Chooser:
+// weights are snapshotted into a scratch fenwick tree for the duration
+// of the call, so concurrent PickN/PickRemove calls against the same
+// Chooser are safe.
+//
+// Like Pick, PickN draws from the RNG configured via
+// NewChooserWithOptions, if any, falling back to the global math/rand
+// source otherwise.
+//
+// It returns ErrInsufficientChoices if n is negative or exceeds the
+// number of entries with nonzero weight.
+func (chs Chooser(T)) PickN(n int) ([]T, error) {
+	weights := make([]int, len(chs.data))
+	nonzero := 0
+	for i, c := range chs.data {
+		weights[i] = int(c.Weight)
+		if c.Weight > 0 {
+			nonzero++
+		}
+	}
+	if n < 0 || n > nonzero {
+		return nil, ErrInsufficientChoices
+	}
+
+	f := newFenwick(weights)
+	picks := make([]T, 0, n)
+	for i := 0; i < n; i++ {
+		r := chs.intn(f.total()) + 1
+		idx := f.find(r)
+		picks = append(picks, chs.data[idx].Item)
+		f.add(idx, -weights[idx])
+		weights[idx] = 0
+	}
+	return picks, nil
+}
+
+// PickRemove draws a single weighted random item from the Chooser
+// without replacement, as a convenience over PickN(1). Like PickN, it
+// leaves the Chooser unchanged: the drawn item is not removed and stays
+// available to later picks. It returns the zero value of T if the
+// Chooser has no entries with nonzero weight.
+func (chs Chooser(T)) PickRemove() T {
+	picks, err := chs.PickN(1)
+	if err != nil {
+		var zero T
+		return zero
+	}
+	return picks[0]
+}
@@ -11,10 +11,21 @@
 package weightedrand
 
 import (
+	"errors"
+	"math"
 	"math/rand"
+	"reflect"
 	"sort"
 )
 
+// ErrNoValidChoices is returned by NewChooser when cs is empty or every
+// Choice has zero weight, leaving nothing for Pick to select from.
+var ErrNoValidChoices = errors.New("weightedrand: no choices with a nonzero weight")
+
+// ErrWeightOverflow is returned by NewChooser when the cumulative sum of
+// the provided weights exceeds math.MaxInt64.
+var ErrWeightOverflow = errors.New("weightedrand: cumulative weight overflows int64")
+
 // Choice is a generic wrapper that can be used to add weights for any item.
 type Choice(type T) struct {
 	Item   T
@@ -28,29 +39,228 @@ func NewChoice(type T)(item T, weight uint) Choice(T) {
 
 // A Chooser caches many possible Choices in a structure designed to improve
 // performance on repeated calls for weighted random selection.
+//
+// A Chooser built by NewChooser is immutable and picks via binary search
+// over a sorted cumulative-totals slice. Calling Add, Remove, or
+// UpdateWeight lazily migrates it to a Fenwick tree keyed by insertion
+// order; once that happens, the order choices were originally stored in
+// (and any order implied by iterating over them) is no longer
+// meaningful. UpdateWeight, and an Add that reuses a slot freed by
+// Remove, are O(log n). Remove scans for the matching item in O(n)
+// before its O(log n) tree update. An Add that grows the tree past its
+// previous size is O(n log n): extending a Fenwick tree's leaf count in
+// place cannot be done as a simple O(log n) update, so it rebuilds.
 type Chooser(type T) struct {
 	data   []Choice(T)
 	totals []int
 	max    int
+
+	tree     *fenwick
+	weights  []int
+	freeList []int
+
+	rngConfig *chooserConfig
 }
 
-// NewChooser initializes a new Chooser for picking from the provided Choices.
-func NewChooser(type T)(cs ...Choice(T)) Chooser(T) {
-	sort.Slice(cs, func(i, j int) bool {
-		return cs[i].Weight < cs[j].Weight
+// NewChooser initializes a new Chooser for picking from the provided
+// Choices. Choices with zero weight are dropped, since they can never
+// be selected and would otherwise take up a slot in the binary search.
+//
+// It returns ErrNoValidChoices if cs is empty or every Choice has zero
+// weight, and ErrWeightOverflow if the cumulative weight exceeds
+// math.MaxInt64.
+func NewChooser(type T)(cs ...Choice(T)) (Chooser(T), error) {
+	filtered := make([]Choice(T), 0, len(cs))
+	for _, c := range cs {
+		if c.Weight > 0 {
+			filtered = append(filtered, c)
+		}
+	}
+	if len(filtered) == 0 {
+		return Chooser(T){}, ErrNoValidChoices
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Weight < filtered[j].Weight
 	})
-	totals := make([]int, len(cs))
-	runningTotal := 0
-	for i, c := range cs {
-		runningTotal += int(c.Weight)
-		totals[i] = runningTotal
+	totals := make([]int, len(filtered))
+	var runningTotal int64
+	for i, c := range filtered {
+		w := int64(c.Weight)
+		if w < 0 || runningTotal > math.MaxInt64-w {
+			return Chooser(T){}, ErrWeightOverflow
+		}
+		runningTotal += w
+		totals[i] = int(runningTotal)
+	}
+	return Chooser(T){data: filtered, totals: totals, max: int(runningTotal)}, nil
+}
+
+// NewChooserWithOptions initializes a new Chooser for picking from the
+// provided Choices, using the RNG configured by opts in place of the
+// global math/rand source. Unrelated Choosers no longer contend on the
+// one global source's lock, and callers can seed the source for
+// reproducible picks or supply crypto/rand for cryptographically strong
+// randomness. See WithRand, WithRandSource, and WithCryptoRand for what
+// each option means for concurrent use of the resulting Chooser.
+//
+// It returns the same errors as NewChooser.
+func NewChooserWithOptions(type T)(opts []Option, cs ...Choice(T)) (Chooser(T), error) {
+	chs, err := NewChooser(cs...)
+	if err != nil {
+		return Chooser(T){}, err
 	}
-	return Chooser(T){data: cs, totals: totals, max: runningTotal}
+	cfg := &chooserConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	chs.rngConfig = cfg
+	return chs, nil
 }
 
-// Pick returns a single weighted random Choice.Item from the Chooser.
+// Pick returns a single weighted random Choice.Item from the Chooser. If
+// Add, Remove, or UpdateWeight has been called on this Chooser, Pick
+// descends the Fenwick tree those mutations maintain; otherwise it uses
+// the sorted cumulative totals built by NewChooser.
+//
+// Pick draws from the RNG configured via NewChooserWithOptions, if any,
+// falling back to the global math/rand source otherwise.
+//
+// Pick is panic-free: it returns the zero value of T if the Chooser is
+// zero-initialized or every weight it holds has been reduced to zero by
+// Remove/UpdateWeight.
 func (chs Chooser(T)) Pick() T {
-	r := rand.Intn(chs.max) + 1
+	if chs.tree != nil {
+		total := chs.tree.total()
+		if total == 0 {
+			var zero T
+			return zero
+		}
+		r := chs.intn(total) + 1
+		return chs.data[chs.tree.find(r)].Item
+	}
+	if chs.max == 0 {
+		var zero T
+		return zero
+	}
+	r := chs.intn(chs.max) + 1
 	i := sort.SearchInts(chs.totals, r)
 	return chs.data[i].Item
 }
+
+// PickSource returns a single weighted random Choice.Item from the
+// Chooser, drawing from src instead of the Chooser's configured or
+// global RNG. This lets callers supply a per-call source, such as one
+// drawn from a sync.Pool of *rand.Rand kept per goroutine, without the
+// lock contention the global math/rand source incurs.
+//
+// Like Pick, PickSource is panic-free and returns the zero value of T
+// under the same conditions.
+func (chs Chooser(T)) PickSource(src rand.Source) T {
+	r := rand.New(src)
+	if chs.tree != nil {
+		total := chs.tree.total()
+		if total == 0 {
+			var zero T
+			return zero
+		}
+		n := r.Intn(total) + 1
+		return chs.data[chs.tree.find(n)].Item
+	}
+	if chs.max == 0 {
+		var zero T
+		return zero
+	}
+	n := r.Intn(chs.max) + 1
+	i := sort.SearchInts(chs.totals, n)
+	return chs.data[i].Item
+}
+
+// intn returns a pseudo-random int in [0, n) using the Chooser's
+// configured RNG, falling back to the global math/rand source. A
+// configured *rand.Rand is not safe for concurrent use on its own, so
+// calls into it are serialized behind chooserConfig.mu.
+func (chs Chooser(T)) intn(n int) int {
+	switch {
+	case chs.rngConfig == nil, chs.rngConfig.rng == nil && !chs.rngConfig.useCrypto:
+		return rand.Intn(n)
+	case chs.rngConfig.useCrypto:
+		return cryptoIntn(n)
+	default:
+		chs.rngConfig.mu.Lock()
+		defer chs.rngConfig.mu.Unlock()
+		return chs.rngConfig.rng.Intn(n)
+	}
+}
+
+// ensureTree lazily migrates the Chooser from its immutable sorted
+// representation to a Fenwick tree keyed by insertion order, the first
+// time a mutation method is called.
+func (chs *Chooser(T)) ensureTree() {
+	if chs.tree != nil {
+		return
+	}
+	weights := make([]int, len(chs.data))
+	for i, c := range chs.data {
+		weights[i] = int(c.Weight)
+	}
+	chs.weights = weights
+	chs.tree = newFenwick(weights)
+}
+
+// Add appends a new Choice to the Chooser, reusing a slot freed by a
+// prior Remove if one is available, and returns the index c was stored
+// at. Since that index may be a reused slot rather than len(data), it
+// is the only reliable way to later target c with UpdateWeight.
+func (chs *Chooser(T)) Add(c Choice(T)) int {
+	chs.ensureTree()
+	w := int(c.Weight)
+	if n := len(chs.freeList); n > 0 {
+		idx := chs.freeList[n-1]
+		chs.freeList = chs.freeList[:n-1]
+		chs.data[idx] = c
+		chs.weights[idx] = w
+		chs.tree.add(idx, w)
+		return idx
+	}
+	chs.data = append(chs.data, c)
+	chs.weights = append(chs.weights, w)
+	chs.tree.grow(w)
+	return len(chs.data) - 1
+}
+
+// Remove deletes the first Choice with a nonzero weight whose Item
+// equals item, reporting whether such an entry was found; entries whose
+// weight is already zero are skipped. The freed slot is recorded for
+// reuse by a later Add rather than shifting the indices of the
+// remaining entries.
+func (chs *Chooser(T)) Remove(item T) bool {
+	chs.ensureTree()
+	for i, c := range chs.data {
+		if chs.weights[i] == 0 || !reflect.DeepEqual(c.Item, item) {
+			continue
+		}
+		chs.tree.add(i, -chs.weights[i])
+		chs.weights[i] = 0
+		chs.data[i].Weight = 0
+		chs.freeList = append(chs.freeList, i)
+		return true
+	}
+	return false
+}
+
+// UpdateWeight sets the weight of the entry at index i (as returned by
+// Add) to w, applying the delta to the underlying Fenwick tree rather
+// than rebuilding it. It reports whether i was in range; an
+// out-of-range i is a no-op rather than a panic.
+func (chs *Chooser(T)) UpdateWeight(i int, w uint) bool {
+	chs.ensureTree()
+	if i < 0 || i >= len(chs.data) {
+		return false
+	}
+	nw := int(w)
+	chs.tree.add(i, nw-chs.weights[i])
+	chs.weights[i] = nw
+	chs.data[i].Weight = w
+	return true
+}
@@ -0,0 +1,184 @@
+package weightedrand
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestNewChooserRejectsNoValidChoices(t *testing.T) {
+	if _, err := NewChooser(int)(); err != ErrNoValidChoices {
+		t.Fatalf("NewChooser() error = %v, want ErrNoValidChoices", err)
+	}
+	if _, err := NewChooser(NewChoice(1, 0), NewChoice(2, 0)); err != ErrNoValidChoices {
+		t.Fatalf("NewChooser(all zero weight) error = %v, want ErrNoValidChoices", err)
+	}
+}
+
+func TestNewChooserRejectsWeightOverflow(t *testing.T) {
+	_, err := NewChooser(NewChoice(1, math.MaxInt64), NewChoice(2, math.MaxInt64))
+	if err != ErrWeightOverflow {
+		t.Fatalf("NewChooser(overflowing weights) error = %v, want ErrWeightOverflow", err)
+	}
+}
+
+func TestPickPanicFreeOnZeroChooser(t *testing.T) {
+	var chs Chooser(int)
+	if got := chs.Pick(); got != 0 {
+		t.Fatalf("Pick() on zero-initialized Chooser = %v, want 0", got)
+	}
+}
+
+func TestPickNDistinctWithoutReplacement(t *testing.T) {
+	chs, err := NewChooser(NewChoice(1, 1), NewChoice(2, 1), NewChoice(3, 1))
+	if err != nil {
+		t.Fatalf("NewChooser: %v", err)
+	}
+
+	picks, err := chs.PickN(3)
+	if err != nil {
+		t.Fatalf("PickN: %v", err)
+	}
+	seen := map(int)bool{}
+	for _, p := range picks {
+		if seen[p] {
+			t.Fatalf("PickN(3) returned %v twice: %v", p, picks)
+		}
+		seen[p] = true
+	}
+}
+
+func TestPickNInsufficientChoices(t *testing.T) {
+	chs, err := NewChooser(NewChoice(1, 1), NewChoice(2, 0))
+	if err != nil {
+		t.Fatalf("NewChooser: %v", err)
+	}
+	if _, err := chs.PickN(2); err != ErrInsufficientChoices {
+		t.Fatalf("PickN(2) error = %v, want ErrInsufficientChoices", err)
+	}
+}
+
+func TestPickNRejectsNegativeN(t *testing.T) {
+	chs, err := NewChooser(NewChoice(1, 1), NewChoice(2, 1))
+	if err != nil {
+		t.Fatalf("NewChooser: %v", err)
+	}
+	if _, err := chs.PickN(-1); err != ErrInsufficientChoices {
+		t.Fatalf("PickN(-1) error = %v, want ErrInsufficientChoices", err)
+	}
+}
+
+func TestPickNUsesConfiguredRand(t *testing.T) {
+	build := func() Chooser(int) {
+		chs, err := NewChooserWithOptions([]Option{WithRandSource(rand.NewSource(42))},
+			NewChoice(1, 1), NewChoice(2, 1), NewChoice(3, 1), NewChoice(4, 1))
+		if err != nil {
+			t.Fatalf("NewChooserWithOptions: %v", err)
+		}
+		return chs
+	}
+
+	a, err := build().PickN(4)
+	if err != nil {
+		t.Fatalf("PickN: %v", err)
+	}
+	b, err := build().PickN(4)
+	if err != nil {
+		t.Fatalf("PickN: %v", err)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("PickN with identically-seeded sources diverged at %d: %v vs %v", i, a, b)
+		}
+	}
+}
+
+func TestConcurrentPickWithConfiguredRandIsRaceFree(t *testing.T) {
+	chs, err := NewChooserWithOptions([]Option{WithRandSource(rand.NewSource(1))},
+		NewChoice(1, 1), NewChoice(2, 1), NewChoice(3, 1))
+	if err != nil {
+		t.Fatalf("NewChooserWithOptions: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				chs.Pick()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAddReturnsIndexAndUpdateWeightBoundsChecks(t *testing.T) {
+	chs, err := NewChooser(NewChoice(1, 1))
+	if err != nil {
+		t.Fatalf("NewChooser: %v", err)
+	}
+
+	idx := chs.Add(NewChoice(2, 5))
+	if !chs.UpdateWeight(idx, 9) {
+		t.Fatalf("UpdateWeight(%d, 9) = false, want true", idx)
+	}
+	if chs.UpdateWeight(99, 1) {
+		t.Fatalf("UpdateWeight(99, 1) = true, want false for an out-of-range index")
+	}
+}
+
+func TestNewAliasChooserRejectsNoValidChoices(t *testing.T) {
+	if _, err := NewAliasChooser(int)(); err != ErrNoValidChoices {
+		t.Fatalf("NewAliasChooser() error = %v, want ErrNoValidChoices", err)
+	}
+	if _, err := NewAliasChooser(NewChoice(1, 0), NewChoice(2, 0)); err != ErrNoValidChoices {
+		t.Fatalf("NewAliasChooser(all zero weight) error = %v, want ErrNoValidChoices", err)
+	}
+}
+
+func TestAliasChooserPickPanicFree(t *testing.T) {
+	var ac AliasChooser(int)
+	if got := ac.Pick(); got != 0 {
+		t.Fatalf("Pick() on zero-initialized AliasChooser = %v, want 0", got)
+	}
+}
+
+func TestFenwickFindAndUpdate(t *testing.T) {
+	f := newFenwick([]int{1, 2, 3, 4})
+	if got := f.total(); got != 10 {
+		t.Fatalf("total() = %d, want 10", got)
+	}
+	if idx := f.find(1); idx != 0 {
+		t.Fatalf("find(1) = %d, want 0", idx)
+	}
+	if idx := f.find(10); idx != 3 {
+		t.Fatalf("find(10) = %d, want 3", idx)
+	}
+
+	f.add(1, -2)
+	if got := f.total(); got != 8 {
+		t.Fatalf("total() after removing index 1's weight = %d, want 8", got)
+	}
+}
+
+func TestFenwickGrowMatchesBulkBuild(t *testing.T) {
+	weights := []int{5, 2, 9, 1, 7}
+
+	grown := newFenwick(nil)
+	for _, w := range weights {
+		grown.grow(w)
+	}
+
+	bulk := newFenwick(weights)
+
+	for i := range weights {
+		if got, want := grown.prefixSum(i), bulk.prefixSum(i); got != want {
+			t.Fatalf("grown.prefixSum(%d) = %d, want %d (bulk-built)", i, got, want)
+		}
+	}
+	if got, want := grown.total(), bulk.total(); got != want {
+		t.Fatalf("grown.total() = %d, want %d (bulk-built)", got, want)
+	}
+}